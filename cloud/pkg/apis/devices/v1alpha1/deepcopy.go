@@ -0,0 +1,148 @@
+/*
+Copyright 2019 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// DeepCopyInto copies the receiver into out.
+func (in *DeviceStatus) DeepCopyInto(out *DeviceStatus) {
+	*out = *in
+	if in.Twins != nil {
+		out.Twins = make([]Twin, len(in.Twins))
+		for i := range in.Twins {
+			in.Twins[i].DeepCopyInto(&out.Twins[i])
+		}
+	}
+	if in.Conditions != nil {
+		out.Conditions = make([]metav1.Condition, len(in.Conditions))
+		copy(out.Conditions, in.Conditions)
+	}
+	if in.RecentErrors != nil {
+		out.RecentErrors = make([]DeviceError, len(in.RecentErrors))
+		copy(out.RecentErrors, in.RecentErrors)
+	}
+}
+
+// DeepCopy returns a deep copy of the receiver.
+func (in *DeviceStatus) DeepCopy() *DeviceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DeviceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies the receiver into out.
+func (in *Twin) DeepCopyInto(out *Twin) {
+	*out = *in
+	in.Desired.DeepCopyInto(&out.Desired)
+	in.Reported.DeepCopyInto(&out.Reported)
+}
+
+// DeepCopy returns a deep copy of the receiver.
+func (in *Twin) DeepCopy() *Twin {
+	if in == nil {
+		return nil
+	}
+	out := new(Twin)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies the receiver into out. Metadata is copied key by key: the `*out = *in`
+// assignment only duplicates the map header, leaving the copy sharing the original's backing map.
+func (in *TwinProperty) DeepCopyInto(out *TwinProperty) {
+	*out = *in
+	if in.Metadata != nil {
+		out.Metadata = make(map[string]string, len(in.Metadata))
+		for k, v := range in.Metadata {
+			out.Metadata[k] = v
+		}
+	}
+}
+
+// DeepCopy returns a deep copy of the receiver.
+func (in *TwinProperty) DeepCopy() *TwinProperty {
+	if in == nil {
+		return nil
+	}
+	out := new(TwinProperty)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies the receiver into out.
+func (in *Device) DeepCopyInto(out *Device) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy returns a deep copy of the receiver.
+func (in *Device) DeepCopy() *Device {
+	if in == nil {
+		return nil
+	}
+	out := new(Device)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject implements runtime.Object.
+func (in *Device) DeepCopyObject() runtime.Object {
+	if in == nil {
+		return nil
+	}
+	return in.DeepCopy()
+}
+
+// DeepCopyInto copies the receiver into out.
+func (in *DeviceList) DeepCopyInto(out *DeviceList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		out.Items = make([]Device, len(in.Items))
+		for i := range in.Items {
+			in.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+}
+
+// DeepCopy returns a deep copy of the receiver.
+func (in *DeviceList) DeepCopy() *DeviceList {
+	if in == nil {
+		return nil
+	}
+	out := new(DeviceList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject implements runtime.Object.
+func (in *DeviceList) DeepCopyObject() runtime.Object {
+	if in == nil {
+		return nil
+	}
+	return in.DeepCopy()
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2019 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package v1alpha1 contains the Device CRD types synced between the cloud and the edge.
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Device is the Schema for the devices API.
+type Device struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DeviceSpec   `json:"spec,omitempty"`
+	Status DeviceStatus `json:"status,omitempty"`
+}
+
+// DeviceList contains a list of Device.
+type DeviceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Device `json:"items"`
+}
+
+// DeviceSpec represents the desired state of a single device instance.
+type DeviceSpec struct {
+	// NodeName is the edge node this device is bound to.
+	NodeName string `json:"nodeName,omitempty"`
+}
+
+// DeviceStatus represents the most recently observed status of the device.
+type DeviceStatus struct {
+	// Twins is the list of device properties, holding both the desired and reported value for each.
+	Twins []Twin `json:"twins,omitempty"`
+	// Conditions represent the device's up-to-date observed state, one per condition type (Ready,
+	// Reachable, MapperHealthy). Populated once the upstream controller has seen at least one
+	// device-state or device-error event for the device.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// RecentErrors is a bounded ring of the most recently reported mapper/protocol errors for this
+	// device, newest last.
+	RecentErrors []DeviceError `json:"recentErrors,omitempty"`
+}
+
+// DeviceError is a single mapper/protocol-level error report, surfaced on status.recentErrors.
+type DeviceError struct {
+	// Source identifies the mapper or protocol binding that reported the error, e.g. "modbus-mapper".
+	Source string `json:"source,omitempty"`
+	// Message is the human-readable error, e.g. "Modbus timeout reading holding register 40001".
+	Message string `json:"message,omitempty"`
+	// Timestamp is when the cloud received the error report.
+	Timestamp metav1.Time `json:"timestamp,omitempty"`
+}
+
+// Twin is a desired/reported pair for a single device property.
+type Twin struct {
+	// PropertyName is the name of the device property as defined on the device model.
+	PropertyName string `json:"propertyName,omitempty"`
+	// Desired is the value applications or users want the property set to.
+	Desired TwinProperty `json:"desired,omitempty"`
+	// Reported is the value the device last reported through its mapper.
+	Reported TwinProperty `json:"reported,omitempty"`
+}
+
+// TwinProperty is the value and metadata of a single reported or desired property.
+type TwinProperty struct {
+	// Value is the property value, always carried as a string and interpreted per the device model.
+	Value string `json:"value"`
+	// Metadata carries additional key/value info about the value, e.g. "type" and "timestamp".
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2019 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package config holds the tunables for the devicecontroller's upstream pipeline.
+package config
+
+import "time"
+
+var (
+	// UpdateDeviceStatusBuffer is the size of the deviceStateChan/deviceErrorChan channels buffering
+	// device-level events awaiting a free worker.
+	UpdateDeviceStatusBuffer = 1024
+
+	// UpdateDeviceStatusWorkers is the number of goroutines draining the coalesced device-status flush
+	// queue.
+	UpdateDeviceStatusWorkers = 1
+
+	// UpdateDeviceStatusFlushInterval is how long the upstream controller waits after the first twin
+	// update for a device before flushing its coalesced status to the API server.
+	UpdateDeviceStatusFlushInterval = time.Second
+
+	// UpdateDeviceStatusMaxBatch is the number of twin updates coalesced into a single pending status
+	// before it is flushed early, regardless of UpdateDeviceStatusFlushInterval.
+	UpdateDeviceStatusMaxBatch = 100
+
+	// UpdateDeviceStateWorkers is the number of goroutines processing device online/offline events.
+	UpdateDeviceStateWorkers = 1
+
+	// UpdateDeviceErrorWorkers is the number of goroutines processing device/mapper error reports.
+	UpdateDeviceErrorWorkers = 1
+
+	// DeviceRecentErrorsMaxLen bounds status.recentErrors so a flapping mapper cannot grow a Device
+	// object without bound.
+	DeviceRecentErrorsMaxLen = 10
+)
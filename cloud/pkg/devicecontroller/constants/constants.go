@@ -0,0 +1,33 @@
+/*
+Copyright 2019 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package constants holds the resource-type strings the devicecontroller matches on messages routed
+// to it over the beehive message layer.
+package constants
+
+const (
+	// ResourceTypeTwinEdgeUpdated is the resource type of a twin-update message sent by a mapper when
+	// a device property's reported value changes.
+	ResourceTypeTwinEdgeUpdated = "twin/edge_updated"
+
+	// ResourceTypeDeviceStateUpdated is the resource type of a device-level online/offline transition
+	// reported by a mapper, independent of any single twin.
+	ResourceTypeDeviceStateUpdated = "device/state_updated"
+
+	// ResourceTypeDeviceErrorReported is the resource type of a mapper connection failure or
+	// protocol-level error (e.g. a Modbus timeout or an OPC-UA session drop).
+	ResourceTypeDeviceErrorReported = "device/error_reported"
+)
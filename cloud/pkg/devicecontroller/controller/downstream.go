@@ -0,0 +1,30 @@
+/*
+Copyright 2019 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import "sync"
+
+// DownstreamController watches the API server and syncs Device objects down to the edge. Only the
+// in-memory cache UpstreamController reads and writes is reproduced here.
+type DownstreamController struct {
+	deviceManager *deviceManager
+}
+
+// deviceManager holds the devices synced down from the API server, keyed by device name.
+type deviceManager struct {
+	Device sync.Map
+}
@@ -17,10 +17,23 @@ limitations under the License.
 package controller
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"strconv"
+	"sync"
+	"time"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/jsonmergepatch"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog"
 
 	beehiveContext "github.com/kubeedge/beehive/pkg/core/context"
@@ -29,28 +42,114 @@ import (
 	"github.com/kubeedge/kubeedge/cloud/pkg/devicecontroller/config"
 	"github.com/kubeedge/kubeedge/cloud/pkg/devicecontroller/constants"
 	"github.com/kubeedge/kubeedge/cloud/pkg/devicecontroller/messagelayer"
+	"github.com/kubeedge/kubeedge/cloud/pkg/devicecontroller/metrics"
 	"github.com/kubeedge/kubeedge/cloud/pkg/devicecontroller/types"
 	"github.com/kubeedge/kubeedge/cloud/pkg/devicecontroller/utils"
 )
 
+// tracer provides the spans covering the devicecontroller's own processing of upstream messages.
+var tracer = otel.Tracer("github.com/kubeedge/kubeedge/cloud/pkg/devicecontroller")
+
+// startSpanFromMessage starts a span for processing msg. beehive's model.MessageHeader does not yet
+// carry a W3C traceparent end to end from the reporting edge-mapper (adding one is tracked as a
+// companion change to github.com/kubeedge/beehive), so this cannot extract a remote parent context
+// today; it starts a root span instead and tags it with the message and parent-message IDs beehive
+// already provides, which is enough to correlate a message's spans across this pipeline's own hops in
+// the backend once the real cross-process propagation lands.
+func startSpanFromMessage(ctx context.Context, msg model.Message, spanName string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, spanName, trace.WithAttributes(
+		attribute.String("message.id", msg.GetID()),
+		attribute.String("message.parent_id", msg.GetParentID()),
+		attribute.String("message.resource", msg.GetResource()),
+	))
+}
+
 // DeviceStatus is structure to patch device status
 type DeviceStatus struct {
 	Status v1alpha1.DeviceStatus `json:"status"`
 }
 
+// reportedTwinStatus is the subset of DeviceStatus the upstream controller owns: the reported side of
+// each twin. It is what gets recorded in LastReportedTwinsAnnotation and diffed against on every patch,
+// so that fields outside our ownership (desired values, status written by other controllers) survive
+// concurrent edits instead of being clobbered by a blind patch built from our in-memory cache.
+type reportedTwinStatus struct {
+	Twins []v1alpha1.Twin `json:"twins"`
+}
+
+// annotationPatch is used to build the "original" and "modified" documents for the 3-way merge: only
+// the annotation we own and the status, nothing else.
+type annotationPatch struct {
+	Metadata metav1.ObjectMeta     `json:"metadata"`
+	Status   v1alpha1.DeviceStatus `json:"status"`
+}
+
 const (
 	// MergePatchType is patch type
 	MergePatchType = "application/merge-patch+json"
 	// ResourceTypeDevices is plural of device resource in apiserver
 	ResourceTypeDevices = "devices"
+	// LastReportedTwinsAnnotation records the reported-twins subobject this controller last patched
+	// onto the device, in the shape of reportedTwinStatus. It plays the role of kubectl apply's
+	// last-applied-configuration annotation: the "original" document in a 3-way merge, so that a new
+	// patch only touches the fields we actually changed rather than overwriting the live object wholesale.
+	LastReportedTwinsAnnotation = "devices.kubeedge.io/last-reported-twins"
+	// StatusSubresource is the status subresource of the Device CRD. The Device CRD must have
+	// `subresources.status` enabled (apiextensions.k8s.io/v1) for this to be reachable; clusters
+	// running an older CRD without it need to re-apply the CRD before upgrading the cloud components,
+	// and RBAC that previously granted only `patch devices` must additionally grant
+	// `patch devices/status` since spec and status are now authorized separately.
+	StatusSubresource = "status"
+	// DeviceStateOnline is the state value mappers report for a reachable device; anything else is
+	// treated as offline/unreachable.
+	DeviceStateOnline = "online"
+	// Condition types surfaced on status.conditions. Ready and Reachable track device-level
+	// online/offline transitions; MapperHealthy tracks the health of the mapper binding talking to it.
+	ConditionReady         = "Ready"
+	ConditionReachable     = "Reachable"
+	ConditionMapperHealthy = "MapperHealthy"
 )
 
+// DeviceStatusClient abstracts the REST call used to patch a device's status, so unit tests can assert
+// that updateDeviceStatus targets the status subresource without standing up a fake API server.
+type DeviceStatusClient interface {
+	Patch(namespace, name string, body []byte) error
+}
+
+// restDeviceStatusClient is the production DeviceStatusClient, backed by the CRD REST client.
+type restDeviceStatusClient struct {
+	crdClient *rest.RESTClient
+}
+
+// Patch implements DeviceStatusClient.
+func (c *restDeviceStatusClient) Patch(namespace, name string, body []byte) error {
+	return c.crdClient.Patch(MergePatchType).Namespace(namespace).Resource(ResourceTypeDevices).Name(name).SubResource(StatusSubresource).Body(body).Do().Error()
+}
+
 // UpstreamController subscribe messages from edge and sync to k8s api server
 type UpstreamController struct {
 	crdClient    *rest.RESTClient
+	statusClient DeviceStatusClient
 	messageLayer messagelayer.MessageLayer
-	// message channel
-	deviceStatusChan chan model.Message
+
+	// pendingStatusLock guards pendingStatus and pendingBatchSize, which are written from
+	// dispatchMessage's goroutine and drained from the updateDeviceStatus workers.
+	pendingStatusLock sync.Mutex
+	// pendingStatus holds, per device, the most recent DeviceStatus merged from twin-update messages
+	// that have not yet been flushed to the API server.
+	pendingStatus map[string]*DeviceStatus
+	// pendingBatchSize counts how many twin-update messages have been merged into pendingStatus since
+	// the last flush, so a device that updates faster than UpdateDeviceStatusFlushInterval can still be flushed
+	// early once UpdateDeviceStatusMaxBatch is reached.
+	pendingBatchSize map[string]int
+	// deviceStatusQueue carries device IDs with a pending status to flush. It naturally dedupes: a
+	// device already queued or being processed is not queued again.
+	deviceStatusQueue workqueue.RateLimitingInterface
+
+	// deviceStateChan and deviceErrorChan carry device-level events the twin model cannot represent:
+	// online/offline transitions and mapper/protocol errors respectively.
+	deviceStateChan chan model.Message
+	deviceErrorChan chan model.Message
 
 	// downstream controller to update device status in cache
 	dc *DownstreamController
@@ -60,13 +159,31 @@ type UpstreamController struct {
 func (uc *UpstreamController) Start() error {
 	klog.Info("Start upstream devicecontroller")
 
-	uc.deviceStatusChan = make(chan model.Message, config.UpdateDeviceStatusBuffer)
+	uc.pendingStatus = make(map[string]*DeviceStatus)
+	uc.pendingBatchSize = make(map[string]int)
+	uc.deviceStatusQueue = workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "deviceStatus")
+	uc.deviceStateChan = make(chan model.Message, config.UpdateDeviceStatusBuffer)
+	uc.deviceErrorChan = make(chan model.Message, config.UpdateDeviceStatusBuffer)
 
 	go uc.dispatchMessage()
 
+	// deviceStatusQueue.Get() blocks until an item is queued or the queue is shut down, so the workers
+	// draining it would never notice beehiveContext being cancelled on their own; shut the queue down
+	// for them once it fires so updateDeviceStatus exits instead of leaking.
+	go func() {
+		<-beehiveContext.Done()
+		uc.deviceStatusQueue.ShutDown()
+	}()
+
 	for i := 0; i < config.UpdateDeviceStatusWorkers; i++ {
 		go uc.updateDeviceStatus()
 	}
+	for i := 0; i < config.UpdateDeviceStateWorkers; i++ {
+		go uc.updateDeviceState()
+	}
+	for i := 0; i < config.UpdateDeviceErrorWorkers; i++ {
+		go uc.updateDeviceError()
+	}
 
 	return nil
 }
@@ -87,18 +204,128 @@ func (uc *UpstreamController) dispatchMessage() {
 
 		klog.Infof("Dispatch message: %s", msg.GetID())
 
+		ctx, span := startSpanFromMessage(context.Background(), msg, "devicecontroller.dispatchMessage")
+
 		resourceType, err := messagelayer.GetResourceType(msg.GetResource())
 		if err != nil {
 			klog.Warningf("Parse message: %s resource type with error: %s", msg.GetID(), err)
+			metrics.MessagesTotal.WithLabelValues("unknown", metrics.ResultError).Inc()
+			span.RecordError(err)
+			span.End()
 			continue
 		}
 		klog.Infof("Message: %s, resource type is: %s", msg.GetID(), resourceType)
 
 		switch resourceType {
 		case constants.ResourceTypeTwinEdgeUpdated:
-			uc.deviceStatusChan <- msg
+			// coalesceTwinUpdate records its own success/error outcome once it has actually tried to
+			// process the message.
+			uc.coalesceTwinUpdate(ctx, msg)
+		case constants.ResourceTypeDeviceStateUpdated:
+			uc.deviceStateChan <- msg
+		case constants.ResourceTypeDeviceErrorReported:
+			uc.deviceErrorChan <- msg
 		default:
 			klog.Warningf("Message: %s, with resource type: %s not intended for device controller", msg.GetID(), resourceType)
+			// resourceType is mapper-supplied and must not be used as a label value directly, or an
+			// edge mapper sending arbitrary resource strings could blow up this metric's cardinality.
+			metrics.MessagesTotal.WithLabelValues(metrics.ResourceTypeUnrecognized, metrics.ResultDropped).Inc()
+		}
+
+		span.End()
+	}
+}
+
+// coalesceTwinUpdate merges a twin-update message into the pending status for its device and schedules
+// a flush. Messages for the same device arriving within the same flush window are merged into a single
+// pending DeviceStatus, so a device reporting at high frequency produces at most one patch per window
+// (or sooner, once UpdateDeviceStatusMaxBatch merged updates have piled up).
+func (uc *UpstreamController) coalesceTwinUpdate(ctx context.Context, msg model.Message) {
+	ctx, span := tracer.Start(ctx, "devicecontroller.coalesceTwinUpdate")
+	defer span.End()
+
+	klog.Infof("Message: %s, operation is: %s, and resource is: %s", msg.GetID(), msg.GetOperation(), msg.GetResource())
+	msgTwin, err := uc.unmarshalDeviceStatusMessage(ctx, msg)
+	if err != nil {
+		klog.Warningf("Unmarshall failed due to error %v", err)
+		span.SetStatus(codes.Error, err.Error())
+		metrics.MessagesTotal.WithLabelValues(constants.ResourceTypeTwinEdgeUpdated, metrics.ResultError).Inc()
+		return
+	}
+	recordTwinSyncLag(msgTwin)
+
+	deviceID, err := messagelayer.GetDeviceID(msg.GetResource())
+	if err != nil {
+		klog.Warning("Failed to get device id")
+		span.SetStatus(codes.Error, err.Error())
+		metrics.MessagesTotal.WithLabelValues(constants.ResourceTypeTwinEdgeUpdated, metrics.ResultError).Inc()
+		return
+	}
+	cacheDevice, ok := uc.loadCacheDevice(deviceID)
+	if !ok {
+		metrics.MessagesTotal.WithLabelValues(constants.ResourceTypeTwinEdgeUpdated, metrics.ResultDropped).Inc()
+		return
+	}
+
+	uc.pendingStatusLock.Lock()
+	pending, pendingAlready := uc.pendingStatus[deviceID]
+	if !pendingAlready {
+		// Deep-copy, not a value copy: Status.Twins is a slice, so `Status: cacheDevice.Status` would
+		// still alias cacheDevice's backing array, and mergeReportedTwins writing into pending.Status.Twins
+		// would mutate the live cache entry up to a full flush window before it's ever patched to the
+		// server (and before flushDeviceStatus's own explicit cache Store()).
+		pending = &DeviceStatus{Status: *cacheDevice.Status.DeepCopy()}
+		uc.pendingStatus[deviceID] = pending
+	} else {
+		metrics.CoalescedEventsTotal.Inc()
+	}
+	mergeReportedTwins(pending, msgTwin)
+	uc.pendingBatchSize[deviceID]++
+	batchFull := uc.pendingBatchSize[deviceID] >= config.UpdateDeviceStatusMaxBatch
+	uc.pendingStatusLock.Unlock()
+
+	switch {
+	case !pendingAlready:
+		uc.deviceStatusQueue.AddAfter(deviceID, config.UpdateDeviceStatusFlushInterval)
+	case batchFull:
+		uc.deviceStatusQueue.Add(deviceID)
+	}
+	metrics.QueueDepth.Set(float64(uc.deviceStatusQueue.Len()))
+	metrics.MessagesTotal.WithLabelValues(constants.ResourceTypeTwinEdgeUpdated, metrics.ResultSuccess).Inc()
+
+	klog.Infof("Message: %s coalesced into pending status for device %s", msg.GetID(), deviceID)
+}
+
+// recordTwinSyncLag observes, for every twin in msgTwin with a reported timestamp, the delay between
+// that timestamp (set by the edge mapper in milliseconds since the epoch) and now.
+func recordTwinSyncLag(msgTwin *types.DeviceTwinUpdate) {
+	for _, twin := range msgTwin.Twin {
+		if twin.Actual == nil || twin.Actual.Metadata == nil || twin.Actual.Metadata.Timestamp == 0 {
+			continue
+		}
+		reportedAt := time.Unix(0, twin.Actual.Metadata.Timestamp*int64(time.Millisecond))
+		metrics.TwinSyncLagSeconds.Observe(time.Since(reportedAt).Seconds())
+	}
+}
+
+// mergeReportedTwins applies each reported twin value in msgTwin onto pending.Status.Twins, matching by
+// PropertyName.
+func mergeReportedTwins(pending *DeviceStatus, msgTwin *types.DeviceTwinUpdate) {
+	for twinName, twin := range msgTwin.Twin {
+		for i, cacheTwin := range pending.Status.Twins {
+			if twinName == cacheTwin.PropertyName && twin.Actual != nil && twin.Actual.Value != nil {
+				reported := v1alpha1.TwinProperty{}
+				reported.Value = *twin.Actual.Value
+				reported.Metadata = make(map[string]string)
+				if twin.Actual.Metadata != nil {
+					reported.Metadata["timestamp"] = strconv.FormatInt(twin.Actual.Metadata.Timestamp, 10)
+				}
+				if twin.Metadata != nil {
+					reported.Metadata["type"] = twin.Metadata.Type
+				}
+				pending.Status.Twins[i].Reported = reported
+				break
+			}
 		}
 	}
 }
@@ -109,85 +336,339 @@ func (uc *UpstreamController) updateDeviceStatus() {
 		case <-beehiveContext.Done():
 			klog.Info("Stop updateDeviceStatus")
 			return
-		case msg := <-uc.deviceStatusChan:
-			klog.Infof("Message: %s, operation is: %s, and resource is: %s", msg.GetID(), msg.GetOperation(), msg.GetResource())
-			msgTwin, err := uc.unmarshalDeviceStatusMessage(msg)
-			if err != nil {
-				klog.Warningf("Unmarshall failed due to error %v", err)
-				continue
-			}
+		default:
+		}
+
+		item, shutdown := uc.deviceStatusQueue.Get()
+		if shutdown {
+			return
+		}
+		uc.flushDeviceStatus(item.(string))
+		uc.deviceStatusQueue.Done(item)
+		metrics.QueueDepth.Set(float64(uc.deviceStatusQueue.Len()))
+	}
+}
+
+// flushDeviceStatus patches the API server with the status coalesced for deviceID, if one is still
+// pending. Nothing is pending here when an earlier flush for the same device already drained it, e.g.
+// the max-batch trigger raced the flush-interval timer's own AddAfter firing.
+func (uc *UpstreamController) flushDeviceStatus(deviceID string) {
+	uc.pendingStatusLock.Lock()
+	deviceStatus, ok := uc.pendingStatus[deviceID]
+	if ok {
+		delete(uc.pendingStatus, deviceID)
+		delete(uc.pendingBatchSize, deviceID)
+	}
+	uc.pendingStatusLock.Unlock()
+	if !ok {
+		return
+	}
+
+	cacheDevice, ok := uc.loadCacheDevice(deviceID)
+	if !ok {
+		return
+	}
+
+	// Store the status in cache so that when update is received by informer, it is not processed by downstream controller
+	cacheDevice.Status = deviceStatus.Status
+	uc.dc.deviceManager.Device.Store(deviceID, cacheDevice)
+
+	if err := uc.patchDeviceStatus(context.Background(), cacheDevice, deviceStatus); err != nil {
+		klog.Errorf("Failed to patch device status of device %s in namespace %s: %v", deviceID, cacheDevice.Namespace, err)
+		return
+	}
+	metrics.EmittedPatchesTotal.Inc()
+	klog.Infof("Device %s status patched successfully", deviceID)
+}
+
+// loadCacheDevice looks up deviceID in the downstream controller's device cache, logging and returning
+// false if it is missing or of the wrong type.
+func (uc *UpstreamController) loadCacheDevice(deviceID string) (*v1alpha1.Device, bool) {
+	device, ok := uc.dc.deviceManager.Device.Load(deviceID)
+	if !ok {
+		klog.Warningf("Device %s does not exist in downstream controller", deviceID)
+		return nil, false
+	}
+	cacheDevice, ok := device.(*v1alpha1.Device)
+	if !ok {
+		klog.Warning("Failed to assert to CacheDevice type")
+		return nil, false
+	}
+	return cacheDevice, true
+}
+
+// newDeviceCondition builds a metav1.Condition in the shape meta.SetStatusCondition expects:
+// ConditionTrue when ok, ConditionFalse otherwise.
+func newDeviceCondition(conditionType string, ok bool, message string) metav1.Condition {
+	status := metav1.ConditionFalse
+	reason := "Unhealthy"
+	if ok {
+		status = metav1.ConditionTrue
+		reason = "Healthy"
+	}
+	return metav1.Condition{
+		Type:    conditionType,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	}
+}
+
+// updateDeviceState translates device online/offline events reported by mappers into the Ready and
+// Reachable conditions on the Device CR.
+func (uc *UpstreamController) updateDeviceState() {
+	for {
+		select {
+		case <-beehiveContext.Done():
+			klog.Info("Stop updateDeviceState")
+			return
+		case msg := <-uc.deviceStateChan:
+			_, span := startSpanFromMessage(context.Background(), msg, "devicecontroller.updateDeviceState")
+
 			deviceID, err := messagelayer.GetDeviceID(msg.GetResource())
 			if err != nil {
 				klog.Warning("Failed to get device id")
+				span.End()
 				continue
 			}
-			device, ok := uc.dc.deviceManager.Device.Load(deviceID)
+			cacheDevice, ok := uc.loadCacheDevice(deviceID)
 			if !ok {
-				klog.Warningf("Device %s does not exist in downstream controller", deviceID)
+				span.End()
 				continue
 			}
-			cacheDevice, ok := device.(*v1alpha1.Device)
-			if !ok {
-				klog.Warning("Failed to assert to CacheDevice type")
+
+			var stateUpdate types.DeviceStateUpdate
+			if err := unmarshalMessageContent(msg, &stateUpdate); err != nil {
+				klog.Warningf("Unmarshal device state update failed due to error %v", err)
+				metrics.MessagesTotal.WithLabelValues(constants.ResourceTypeDeviceStateUpdated, metrics.ResultError).Inc()
+				span.SetStatus(codes.Error, err.Error())
+				span.End()
 				continue
 			}
-			deviceStatus := &DeviceStatus{Status: cacheDevice.Status}
-			for twinName, twin := range msgTwin.Twin {
-				for i, cacheTwin := range deviceStatus.Status.Twins {
-					if twinName == cacheTwin.PropertyName && twin.Actual != nil && twin.Actual.Value != nil {
-						reported := v1alpha1.TwinProperty{}
-						reported.Value = *twin.Actual.Value
-						reported.Metadata = make(map[string]string)
-						if twin.Actual.Metadata != nil {
-							reported.Metadata["timestamp"] = strconv.FormatInt(twin.Actual.Metadata.Timestamp, 10)
-						}
-						if twin.Metadata != nil {
-							reported.Metadata["type"] = twin.Metadata.Type
-						}
-						deviceStatus.Status.Twins[i].Reported = reported
-						break
-					}
-				}
+
+			reachable := stateUpdate.State == DeviceStateOnline
+			message := fmt.Sprintf("device reported state %q", stateUpdate.State)
+			conditions := []metav1.Condition{
+				newDeviceCondition(ConditionReachable, reachable, message),
+				newDeviceCondition(ConditionReady, reachable, message),
 			}
+			if err := uc.patchDeviceConditions(cacheDevice.Namespace, deviceID, conditions); err != nil {
+				klog.Errorf("Failed to patch conditions for device %s: %v", deviceID, err)
+				metrics.MessagesTotal.WithLabelValues(constants.ResourceTypeDeviceStateUpdated, metrics.ResultError).Inc()
+				span.SetStatus(codes.Error, err.Error())
+			} else {
+				metrics.MessagesTotal.WithLabelValues(constants.ResourceTypeDeviceStateUpdated, metrics.ResultSuccess).Inc()
+			}
+			span.End()
+		}
+	}
+}
 
-			// Store the status in cache so that when update is received by informer, it is not processed by downstream controller
-			cacheDevice.Status = deviceStatus.Status
-			uc.dc.deviceManager.Device.Store(deviceID, cacheDevice)
+// updateDeviceError translates mapper connection failures and protocol-level errors (e.g. Modbus
+// timeouts, OPC-UA session drops) into the MapperHealthy condition and an entry in status.recentErrors.
+func (uc *UpstreamController) updateDeviceError() {
+	for {
+		select {
+		case <-beehiveContext.Done():
+			klog.Info("Stop updateDeviceError")
+			return
+		case msg := <-uc.deviceErrorChan:
+			_, span := startSpanFromMessage(context.Background(), msg, "devicecontroller.updateDeviceError")
 
-			body, err := json.Marshal(deviceStatus)
+			deviceID, err := messagelayer.GetDeviceID(msg.GetResource())
 			if err != nil {
-				klog.Errorf("Failed to marshal device status %v", deviceStatus)
+				klog.Warning("Failed to get device id")
+				span.End()
+				continue
+			}
+			cacheDevice, ok := uc.loadCacheDevice(deviceID)
+			if !ok {
+				span.End()
 				continue
 			}
-			result := uc.crdClient.Patch(MergePatchType).Namespace(cacheDevice.Namespace).Resource(ResourceTypeDevices).Name(deviceID).Body(body).Do()
-			if result.Error() != nil {
-				klog.Errorf("Failed to patch device status %v of device %v in namespace %v", deviceStatus, deviceID, cacheDevice.Namespace)
+
+			var errReport types.DeviceErrorReport
+			if err := unmarshalMessageContent(msg, &errReport); err != nil {
+				klog.Warningf("Unmarshal device error report failed due to error %v", err)
+				metrics.MessagesTotal.WithLabelValues(constants.ResourceTypeDeviceErrorReported, metrics.ResultError).Inc()
+				span.SetStatus(codes.Error, err.Error())
+				span.End()
 				continue
 			}
-			klog.Infof("Message: %s process successfully", msg.GetID())
+
+			condition := newDeviceCondition(ConditionMapperHealthy, false, errReport.Message)
+			recentErr := v1alpha1.DeviceError{
+				Source:    errReport.Source,
+				Message:   errReport.Message,
+				Timestamp: metav1.Now(),
+			}
+			if err := uc.patchDeviceError(cacheDevice.Namespace, deviceID, condition, recentErr); err != nil {
+				klog.Errorf("Failed to patch error state for device %s: %v", deviceID, err)
+				metrics.MessagesTotal.WithLabelValues(constants.ResourceTypeDeviceErrorReported, metrics.ResultError).Inc()
+				span.SetStatus(codes.Error, err.Error())
+			} else {
+				metrics.MessagesTotal.WithLabelValues(constants.ResourceTypeDeviceErrorReported, metrics.ResultSuccess).Inc()
+			}
+			span.End()
 		}
 	}
 }
 
-func (uc *UpstreamController) unmarshalDeviceStatusMessage(msg model.Message) (*types.DeviceTwinUpdate, error) {
-	content := msg.GetContent()
-	twinUpdate := &types.DeviceTwinUpdate{}
-	var contentData []byte
-	var err error
-	contentData, ok := content.([]byte)
-	if !ok {
-		contentData, err = json.Marshal(content)
+// patchDeviceConditions applies each condition to the device's live status via meta.SetStatusCondition,
+// which only bumps LastTransitionTime when a condition's Status actually changes, then patches just the
+// conditions field so this never races with the twin-status coalescing path's patches to status.twins.
+func (uc *UpstreamController) patchDeviceConditions(namespace, deviceID string, conditions []metav1.Condition) error {
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		current := &v1alpha1.Device{}
+		if err := uc.crdClient.Get().Namespace(namespace).Resource(ResourceTypeDevices).Name(deviceID).Do().Into(current); err != nil {
+			return err
+		}
+
+		changed := false
+		for _, condition := range conditions {
+			if meta.SetStatusCondition(&current.Status.Conditions, condition) {
+				changed = true
+			}
+		}
+		if !changed {
+			return nil
+		}
+
+		// Conditions is tagged omitempty in v1alpha1.DeviceStatus, so a fresh struct with only Conditions
+		// set serializes with no "twins" key at all; under MergePatchType an absent key leaves
+		// status.twins untouched on the server instead of clobbering it, which is also why this can't
+		// race the twin-coalescing path's patches to status.twins.
+		body, err := json.Marshal(DeviceStatus{Status: v1alpha1.DeviceStatus{Conditions: current.Status.Conditions}})
+		if err != nil {
+			return err
+		}
+		return uc.statusClient.Patch(namespace, deviceID, body)
+	})
+}
+
+// patchDeviceError sets condition and appends recentErr to status.recentErrors, trimming it to
+// config.DeviceRecentErrorsMaxLen so a flapping mapper cannot grow the Device object without bound.
+func (uc *UpstreamController) patchDeviceError(namespace, deviceID string, condition metav1.Condition, recentErr v1alpha1.DeviceError) error {
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		current := &v1alpha1.Device{}
+		if err := uc.crdClient.Get().Namespace(namespace).Resource(ResourceTypeDevices).Name(deviceID).Do().Into(current); err != nil {
+			return err
+		}
+
+		meta.SetStatusCondition(&current.Status.Conditions, condition)
+		recentErrors := append(current.Status.RecentErrors, recentErr)
+		if len(recentErrors) > config.DeviceRecentErrorsMaxLen {
+			recentErrors = recentErrors[len(recentErrors)-config.DeviceRecentErrorsMaxLen:]
+		}
+		// Conditions and RecentErrors only (see patchDeviceConditions): Twins is left unset, which
+		// omitempty drops from the JSON entirely rather than emitting "twins":null, so this patch leaves
+		// status.twins untouched instead of racing the twin-coalescing path's own patches to it.
+		body, err := json.Marshal(DeviceStatus{Status: v1alpha1.DeviceStatus{
+			Conditions:   current.Status.Conditions,
+			RecentErrors: recentErrors,
+		}})
 		if err != nil {
-			return nil, err
+			return err
 		}
+		return uc.statusClient.Patch(namespace, deviceID, body)
+	})
+}
+
+// patchDeviceStatus patches cacheDevice's status on the server using a 3-way JSON merge patch, modeled
+// on `kubectl apply`: the "original" is the reported-twins subobject we last patched (recorded in
+// LastReportedTwinsAnnotation on the live object), the "modified" is the newly coalesced twins, and the
+// "current" is the object as it exists on the server right now. This way a patch only overwrites the
+// twins we actually updated, leaving concurrent edits from users or other controllers intact. Because
+// reading "current" and applying the patch are not atomic, the whole operation is retried on conflict.
+func (uc *UpstreamController) patchDeviceStatus(ctx context.Context, cacheDevice *v1alpha1.Device, deviceStatus *DeviceStatus) error {
+	_, span := tracer.Start(ctx, "devicecontroller.patchDeviceStatus")
+	defer span.End()
+
+	start := time.Now()
+	defer func() { metrics.PatchDurationSeconds.Observe(time.Since(start).Seconds()) }()
+
+	deviceID := cacheDevice.Name
+
+	reportedRaw, err := json.Marshal(reportedTwinStatus{Twins: deviceStatus.Status.Twins})
+	if err != nil {
+		return fmt.Errorf("failed to marshal reported twins for device %s: %v", deviceID, err)
+	}
+
+	modifiedRaw, err := json.Marshal(annotationPatch{
+		Metadata: metav1.ObjectMeta{Annotations: map[string]string{LastReportedTwinsAnnotation: string(reportedRaw)}},
+		Status:   v1alpha1.DeviceStatus{Twins: deviceStatus.Status.Twins},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal device status patch for device %s: %v", deviceID, err)
 	}
-	err = json.Unmarshal(contentData, twinUpdate)
+
+	err = retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		current := &v1alpha1.Device{}
+		if err := uc.crdClient.Get().Namespace(cacheDevice.Namespace).Resource(ResourceTypeDevices).Name(deviceID).Do().Into(current); err != nil {
+			return err
+		}
+
+		currentRaw, err := json.Marshal(current)
+		if err != nil {
+			return err
+		}
+
+		originalRaw := []byte("{}")
+		if last, ok := current.Annotations[LastReportedTwinsAnnotation]; ok {
+			var lastReported reportedTwinStatus
+			if err := json.Unmarshal([]byte(last), &lastReported); err != nil {
+				return fmt.Errorf("failed to unmarshal %s annotation for device %s: %v", LastReportedTwinsAnnotation, deviceID, err)
+			}
+			originalRaw, err = json.Marshal(annotationPatch{
+				Metadata: metav1.ObjectMeta{Annotations: map[string]string{LastReportedTwinsAnnotation: last}},
+				Status:   v1alpha1.DeviceStatus{Twins: lastReported.Twins},
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		patch, err := jsonmergepatch.CreateThreeWayJSONMergePatch(originalRaw, modifiedRaw, currentRaw)
+		if err != nil {
+			return fmt.Errorf("failed to create 3-way merge patch for device %s: %v", deviceID, err)
+		}
+
+		return uc.statusClient.Patch(cacheDevice.Namespace, deviceID, patch)
+	})
 	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+func (uc *UpstreamController) unmarshalDeviceStatusMessage(ctx context.Context, msg model.Message) (*types.DeviceTwinUpdate, error) {
+	_, span := tracer.Start(ctx, "devicecontroller.unmarshalDeviceStatusMessage")
+	defer span.End()
+
+	twinUpdate := &types.DeviceTwinUpdate{}
+	if err := unmarshalMessageContent(msg, twinUpdate); err != nil {
+		span.SetStatus(codes.Error, err.Error())
 		return nil, err
 	}
 	return twinUpdate, nil
 }
 
+// unmarshalMessageContent decodes msg's content into out. Content normally arrives as []byte, but
+// beehive's in-process message layer can hand it over already as the concrete Go value, so it is
+// re-marshaled first in that case.
+func unmarshalMessageContent(msg model.Message, out interface{}) error {
+	content := msg.GetContent()
+	contentData, ok := content.([]byte)
+	if !ok {
+		var err error
+		contentData, err = json.Marshal(content)
+		if err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(contentData, out)
+}
+
 // NewUpstreamController create UpstreamController from config
 func NewUpstreamController(dc *DownstreamController) (*UpstreamController, error) {
 	config, err := utils.KubeConfig()
@@ -198,6 +679,7 @@ func NewUpstreamController(dc *DownstreamController) (*UpstreamController, error
 	}
 	uc := &UpstreamController{
 		crdClient:    crdcli,
+		statusClient: &restDeviceStatusClient{crdClient: crdcli},
 		messageLayer: ml,
 		dc:           dc,
 	}
@@ -0,0 +1,226 @@
+/*
+Copyright 2019 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/kubeedge/beehive/pkg/core/model"
+	"github.com/kubeedge/kubeedge/cloud/pkg/apis/devices/v1alpha1"
+	"github.com/kubeedge/kubeedge/cloud/pkg/devicecontroller/types"
+)
+
+// fakeStatusClient is a DeviceStatusClient that records the last patch body it was given instead of
+// sending it anywhere, so tests can assert on exactly what a patch would have touched.
+type fakeStatusClient struct {
+	body []byte
+}
+
+func (f *fakeStatusClient) Patch(namespace, name string, body []byte) error {
+	f.body = body
+	return nil
+}
+
+// newTestCRDClient builds a *rest.RESTClient against a test server that always serves device for any
+// Get(), so patchDeviceStatus/patchDeviceConditions/patchDeviceError can run against it unmodified.
+func newTestCRDClient(t *testing.T, device *v1alpha1.Device) *rest.RESTClient {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		body, err := json.Marshal(device)
+		if err != nil {
+			t.Fatalf("failed to marshal fixture device: %v", err)
+		}
+		w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("AddToScheme() error = %v", err)
+	}
+	codecs := serializer.NewCodecFactory(scheme)
+
+	crdClient, err := rest.RESTClientFor(&rest.Config{
+		Host: server.URL,
+		ContentConfig: rest.ContentConfig{
+			GroupVersion:         &v1alpha1.SchemeGroupVersion,
+			NegotiatedSerializer: codecs.WithoutConversion(),
+		},
+	})
+	if err != nil {
+		t.Fatalf("RESTClientFor() error = %v", err)
+	}
+	return crdClient
+}
+
+// TestRestDeviceStatusClientPatchTargetsStatusSubresource asserts that Patch addresses the
+// devices/status subresource rather than the devices resource itself, so a regression here (and the
+// matching CRD/RBAC change it depends on) is caught without standing up a real API server.
+func TestRestDeviceStatusClientPatchTargetsStatusSubresource(t *testing.T) {
+	var gotPath, gotMethod string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotMethod = r.Method
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("AddToScheme() error = %v", err)
+	}
+	codecs := serializer.NewCodecFactory(scheme)
+
+	restConfig := &rest.Config{
+		Host: server.URL,
+		ContentConfig: rest.ContentConfig{
+			GroupVersion:         &v1alpha1.SchemeGroupVersion,
+			NegotiatedSerializer: codecs.WithoutConversion(),
+		},
+	}
+	crdClient, err := rest.RESTClientFor(restConfig)
+	if err != nil {
+		t.Fatalf("RESTClientFor() error = %v", err)
+	}
+
+	client := &restDeviceStatusClient{crdClient: crdClient}
+	if err := client.Patch("default", "dev1", []byte(`{"status":{}}`)); err != nil {
+		t.Fatalf("Patch() error = %v", err)
+	}
+
+	if gotMethod != http.MethodPatch {
+		t.Errorf("Patch() used method %q, want %q", gotMethod, http.MethodPatch)
+	}
+	if !strings.HasSuffix(gotPath, "/devices/dev1/status") {
+		t.Errorf("Patch() requested path %q, want suffix %q", gotPath, "/devices/dev1/status")
+	}
+}
+
+// TestPatchDeviceStatusOnlyTouchesOwnedFields asserts that the 3-way merge patch patchDeviceStatus
+// builds never carries a field it doesn't own (spec, or an unrelated annotation), only status and the
+// bookkeeping annotation it uses to track what it last reported.
+func TestPatchDeviceStatusOnlyTouchesOwnedFields(t *testing.T) {
+	existing := &v1alpha1.Device{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "dev1",
+			Namespace: "default",
+			Annotations: map[string]string{
+				"unrelated.example.com/owned-by-someone-else": "keep-me",
+			},
+		},
+		Spec: v1alpha1.DeviceSpec{NodeName: "edge-node-1"},
+		Status: v1alpha1.DeviceStatus{
+			Twins: []v1alpha1.Twin{{PropertyName: "temperature", Reported: v1alpha1.TwinProperty{Value: "20"}}},
+		},
+	}
+
+	statusClient := &fakeStatusClient{}
+	uc := &UpstreamController{
+		crdClient:    newTestCRDClient(t, existing),
+		statusClient: statusClient,
+	}
+
+	newStatus := &DeviceStatus{Status: v1alpha1.DeviceStatus{
+		Twins: []v1alpha1.Twin{{PropertyName: "temperature", Reported: v1alpha1.TwinProperty{Value: "21"}}},
+	}}
+	if err := uc.patchDeviceStatus(context.Background(), existing, newStatus); err != nil {
+		t.Fatalf("patchDeviceStatus() error = %v", err)
+	}
+
+	var patch map[string]interface{}
+	if err := json.Unmarshal(statusClient.body, &patch); err != nil {
+		t.Fatalf("failed to unmarshal patch body %s: %v", statusClient.body, err)
+	}
+
+	if _, ok := patch["spec"]; ok {
+		t.Errorf("patch touched spec, want it left alone: %s", statusClient.body)
+	}
+	if metadata, ok := patch["metadata"].(map[string]interface{}); ok {
+		if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+			if _, ok := annotations["unrelated.example.com/owned-by-someone-else"]; ok {
+				t.Errorf("patch touched an annotation it doesn't own: %s", statusClient.body)
+			}
+		}
+	}
+}
+
+// TestCoalesceTwinUpdateDoesNotMutateCacheBeforeFlush asserts that merging a twin-update message into
+// the pending status for a device leaves the downstream controller's cached Device untouched until
+// flushDeviceStatus actually runs, guarding against pending status aliasing the cached Twins slice.
+func TestCoalesceTwinUpdateDoesNotMutateCacheBeforeFlush(t *testing.T) {
+	cacheDevice := &v1alpha1.Device{
+		ObjectMeta: metav1.ObjectMeta{Name: "dev1", Namespace: "default"},
+		Status: v1alpha1.DeviceStatus{
+			Twins: []v1alpha1.Twin{{PropertyName: "temperature", Reported: v1alpha1.TwinProperty{Value: "20"}}},
+		},
+	}
+
+	dc := &DownstreamController{deviceManager: &deviceManager{}}
+	dc.deviceManager.Device.Store("dev1", cacheDevice)
+
+	uc := &UpstreamController{
+		crdClient:         newTestCRDClient(t, cacheDevice),
+		statusClient:      &fakeStatusClient{},
+		dc:                dc,
+		pendingStatus:     make(map[string]*DeviceStatus),
+		pendingBatchSize:  make(map[string]int),
+		deviceStatusQueue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "test"),
+	}
+
+	reported := "21"
+	content := types.DeviceTwinUpdate{Twin: map[string]*types.MsgTwin{
+		"temperature": {Actual: &types.TwinValue{Value: &reported}},
+	}}
+	msg := model.NewMessage("").BuildRouter("test", "test", "default/device/dev1/twin/edge_updated", "update").FillBody(content)
+
+	uc.coalesceTwinUpdate(context.Background(), *msg)
+
+	cached, ok := uc.loadCacheDevice("dev1")
+	if !ok {
+		t.Fatalf("device dev1 missing from cache after coalesceTwinUpdate")
+	}
+	if got := cached.Status.Twins[0].Reported.Value; got != "20" {
+		t.Errorf("cache mutated before flush: reported value = %q, want unchanged %q", got, "20")
+	}
+
+	uc.flushDeviceStatus("dev1")
+
+	cached, ok = uc.loadCacheDevice("dev1")
+	if !ok {
+		t.Fatalf("device dev1 missing from cache after flushDeviceStatus")
+	}
+	if got := cached.Status.Twins[0].Reported.Value; got != "21" {
+		t.Errorf("cache not updated after flush: reported value = %q, want %q", got, "21")
+	}
+	if _, pending := uc.pendingStatus["dev1"]; pending {
+		t.Errorf("pending status for dev1 still present after flush")
+	}
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2019 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package messagelayer mediates the devicecontroller's access to the beehive message group it
+// receives edge-reported device updates on.
+package messagelayer
+
+import (
+	"fmt"
+	"strings"
+
+	beehiveContext "github.com/kubeedge/beehive/pkg/core/context"
+	"github.com/kubeedge/beehive/pkg/core/model"
+)
+
+// moduleName is the beehive module this controller registers under and receives messages on.
+const moduleName = "devicecontroller"
+
+// resourceSeparator joins the segments of a message's Resource field, e.g.
+// "<namespace>/device/<deviceID>/twin/edge_updated".
+const resourceSeparator = "/"
+
+// MessageLayer abstracts the beehive message group devicecontroller receives edge-reported device
+// updates from.
+type MessageLayer interface {
+	Receive() (model.Message, error)
+}
+
+// contextMessageLayer is the production MessageLayer, backed by beehive's in-process context.
+type contextMessageLayer struct{}
+
+// Receive implements MessageLayer.
+func (*contextMessageLayer) Receive() (model.Message, error) {
+	return beehiveContext.Receive(moduleName)
+}
+
+// NewMessageLayer creates the production MessageLayer.
+func NewMessageLayer() (MessageLayer, error) {
+	return &contextMessageLayer{}, nil
+}
+
+// GetResourceType returns the last two resource segments of resource, the resource type a message's
+// Resource field encodes (e.g. "twin/edge_updated").
+func GetResourceType(resource string) (string, error) {
+	segments := strings.Split(resource, resourceSeparator)
+	if len(segments) < 2 {
+		return "", fmt.Errorf("invalid resource %s", resource)
+	}
+	return strings.Join(segments[len(segments)-2:], resourceSeparator), nil
+}
+
+// GetDeviceID extracts the device ID segment from a message's Resource field.
+func GetDeviceID(resource string) (string, error) {
+	segments := strings.Split(resource, resourceSeparator)
+	for i, segment := range segments {
+		if segment == "device" && i+1 < len(segments) {
+			return segments[i+1], nil
+		}
+	}
+	return "", fmt.Errorf("no device id found in resource %s", resource)
+}
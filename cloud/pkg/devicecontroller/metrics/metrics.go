@@ -0,0 +1,86 @@
+/*
+Copyright 2019 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics exposes the Prometheus collectors the upstream devicecontroller uses to make
+// twin-sync lag across edge nodes observable, instead of relying on grepping klog.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Result label values for MessagesTotal.
+const (
+	ResultSuccess = "success"
+	ResultError   = "error"
+	ResultDropped = "dropped"
+)
+
+// ResourceTypeUnrecognized is the resource_type label MessagesTotal is recorded under for a message
+// whose resource type isn't one devicecontroller handles. The mapper-supplied resource type itself must
+// never be used as a label value: it is attacker/bug controlled and would otherwise give an edge mapper
+// unbounded Prometheus label cardinality on this metric.
+const ResourceTypeUnrecognized = "unrecognized"
+
+var (
+	// MessagesTotal counts upstream messages dispatched from the edge, labeled by the resource type
+	// parsed off the message and the outcome of handling it.
+	MessagesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kubeedge_devicecontroller_upstream_messages_total",
+		Help: "Total number of upstream messages processed by the device controller, by resource type and result.",
+	}, []string{"resource_type", "result"})
+
+	// PatchDurationSeconds observes how long a single device status patch (including any conflict
+	// retries) takes to reach the API server.
+	PatchDurationSeconds = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "kubeedge_devicecontroller_upstream_patch_duration_seconds",
+		Help:    "Time spent patching a device's status on the API server, including conflict retries.",
+		Buckets: prometheus.DefBuckets,
+	})
+
+	// QueueDepth reports how many device IDs are currently queued and ready to be flushed. Callers set
+	// this from the length of the queue driving the flush workers; devices still waiting out their
+	// flush interval are not counted.
+	QueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "kubeedge_devicecontroller_upstream_queue_depth",
+		Help: "Number of devices with a status update due and queued for patching.",
+	})
+
+	// TwinSyncLagSeconds observes the delay between a twin's reported value changing on the edge
+	// (Actual.Metadata.Timestamp) and the device controller processing that change in the cloud.
+	TwinSyncLagSeconds = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "kubeedge_devicecontroller_twin_sync_lag_seconds",
+		Help:    "Delay between a twin's reported value changing on the edge and the device controller observing it.",
+		Buckets: prometheus.DefBuckets,
+	})
+
+	// CoalescedEventsTotal counts twin-update messages merged into an already-pending device status
+	// update instead of producing their own patch.
+	CoalescedEventsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "kubeedge_devicecontroller_upstream_coalesced_events_total",
+		Help: "Number of twin-update messages merged into an already-pending device status update instead of producing their own patch.",
+	})
+
+	// EmittedPatchesTotal counts device status patches sent to the API server.
+	EmittedPatchesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "kubeedge_devicecontroller_upstream_emitted_patches_total",
+		Help: "Number of device status patches sent to the API server.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(MessagesTotal, PatchDurationSeconds, QueueDepth, TwinSyncLagSeconds, CoalescedEventsTotal, EmittedPatchesTotal)
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2019 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package types holds the wire payloads of the messages the devicecontroller's upstream pipeline
+// receives from edge mappers.
+package types
+
+// DeviceTwinUpdate is the payload of a constants.ResourceTypeTwinEdgeUpdated message: the reported
+// value of each twin property that changed on the edge, keyed by property name.
+type DeviceTwinUpdate struct {
+	Twin map[string]*MsgTwin `json:"twin"`
+}
+
+// MsgTwin carries one property's reported value and type metadata as sent by the edge mapper.
+type MsgTwin struct {
+	Actual   *TwinValue    `json:"actual,omitempty"`
+	Metadata *TypeMetadata `json:"metadata,omitempty"`
+}
+
+// TwinValue is a property's reported value together with when it was observed.
+type TwinValue struct {
+	Value    *string        `json:"value,omitempty"`
+	Metadata *ValueMetadata `json:"metadata,omitempty"`
+}
+
+// ValueMetadata carries when a reported value was observed, in milliseconds since the epoch.
+type ValueMetadata struct {
+	Timestamp int64 `json:"timestamp,omitempty"`
+}
+
+// TypeMetadata carries the declared type of a twin property, e.g. "int", "string".
+type TypeMetadata struct {
+	Type string `json:"type,omitempty"`
+}
+
+// DeviceStateUpdate is the payload of a constants.ResourceTypeDeviceStateUpdated message.
+type DeviceStateUpdate struct {
+	// State is the device's reported state, e.g. "online" or "offline".
+	State string `json:"state"`
+}
+
+// DeviceErrorReport is the payload of a constants.ResourceTypeDeviceErrorReported message.
+type DeviceErrorReport struct {
+	// Source identifies the mapper or protocol binding that hit the error, e.g. "modbus-mapper".
+	Source string `json:"source"`
+	// Message is the human-readable error.
+	Message string `json:"message"`
+}